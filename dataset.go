@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 
 	"github.com/unixpickle/anynet/anyff"
@@ -40,6 +41,21 @@ type DataSet struct {
 	// the sample bitmaps.
 	Width  int
 	Height int
+
+	// NumClasses is the number of distinct labels that
+	// can appear in Samples. If it is 0, callers should
+	// assume the traditional 10-digit MNIST classes.
+	NumClasses int
+}
+
+// numClasses returns d.NumClasses, falling back to the
+// original 10-digit default for DataSets that predate
+// the field (e.g. ones built by hand in tests).
+func (d DataSet) numClasses() int {
+	if d.NumClasses == 0 {
+		return 10
+	}
+	return d.NumClasses
 }
 
 func LoadTrainingDataSet() DataSet {
@@ -51,29 +67,118 @@ func LoadTestingDataSet() DataSet {
 }
 
 func loadDataSet(prefix string) DataSet {
-	labelFilename := prefix + "-labels-idx1-ubyte.gz"
-	imageFilename := prefix + "-images-idx3-ubyte.gz"
-	intensities, w, h, err := readIntensities(assetReader(imageFilename))
+	dataSet, err := ReadIDXImages(assetReader(prefix + "-images-idx3-ubyte.gz"))
 	if err != nil {
 		panic("failed to read images: " + err.Error())
 	}
-	labels, err := readLabels(assetReader(labelFilename), len(intensities))
+	labels, err := ReadIDXLabels(assetReader(prefix+"-labels-idx1-ubyte.gz"), len(dataSet.Samples))
 	if err != nil {
 		panic("failed to read labels: " + err.Error())
 	}
+	for i, label := range labels {
+		dataSet.Samples[i].Label = label
+	}
+	return dataSet
+}
+
+// ReadIDXImages parses an IDX3 image file from r into a
+// DataSet with NumClasses set to 10. Every sample's Label
+// is left at 0; pair this with ReadIDXLabels (or set
+// Samples[i].Label directly) to fill them in.
+func ReadIDXImages(r io.Reader) (DataSet, error) {
+	intensities, w, h, err := readIntensities(r)
+	if err != nil {
+		return DataSet{}, err
+	}
+
 	var dataSet DataSet
 	dataSet.Width = w
 	dataSet.Height = h
+	dataSet.NumClasses = 10
 	dataSet.Samples = make([]Sample, len(intensities))
 	for i := range dataSet.Samples {
 		floats := make([]float64, len(intensities[i]))
-		for i, x := range intensities[i] {
-			floats[i] = float64(x) / 255.0
+		for j, x := range intensities[i] {
+			floats[j] = float64(x) / 255.0
 		}
 		dataSet.Samples[i].Intensities = floats
-		dataSet.Samples[i].Label = labels[i]
 	}
-	return dataSet
+	return dataSet, nil
+}
+
+// ReadIDXLabels parses an IDX1 label file containing n
+// labels from r.
+func ReadIDXLabels(r io.Reader, n int) ([]int, error) {
+	return readLabels(r, n)
+}
+
+// LoadDataSet reads a DataSet from an IDX images file and
+// an IDX labels file on disk. Either path may be gzipped;
+// this is detected from a ".gz" extension.
+//
+// NumClasses is set to one more than the largest label
+// seen, so that DataSets with fewer than 10 classes (such
+// as some EMNIST splits) report their true class count.
+func LoadDataSet(imagesPath, labelsPath string) (DataSet, error) {
+	imageReader, err := openMaybeGzip(imagesPath)
+	if err != nil {
+		return DataSet{}, err
+	}
+	defer imageReader.Close()
+
+	labelReader, err := openMaybeGzip(labelsPath)
+	if err != nil {
+		return DataSet{}, err
+	}
+	defer labelReader.Close()
+
+	dataSet, err := ReadIDXImages(imageReader)
+	if err != nil {
+		return DataSet{}, err
+	}
+	labels, err := ReadIDXLabels(labelReader, len(dataSet.Samples))
+	if err != nil {
+		return DataSet{}, err
+	}
+
+	numClasses := 0
+	for i, label := range labels {
+		dataSet.Samples[i].Label = label
+		if label+1 > numClasses {
+			numClasses = label + 1
+		}
+	}
+	dataSet.NumClasses = numClasses
+
+	return dataSet, nil
+}
+
+func openMaybeGzip(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(strings.ToLower(path), ".gz") {
+		return f, nil
+	}
+	gzReader, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: gzReader, f: f}, nil
+}
+
+// gzipFile closes both the gzip.Reader and the underlying
+// file it wraps.
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.f.Close()
 }
 
 // IntensityVectors returns a slice of intensity
@@ -95,9 +200,10 @@ func (d DataSet) IntensityVectors() [][]float64 {
 // networks where the output of the network is a
 // vector of probabilities.
 func (d DataSet) LabelVectors() [][]float64 {
+	n := d.numClasses()
 	res := make([][]float64, len(d.Samples))
 	for i, sample := range d.Samples {
-		res[i] = make([]float64, 10)
+		res[i] = make([]float64, n)
 		res[i][sample.Label] = 1
 	}
 	return res
@@ -122,8 +228,9 @@ func (d DataSet) NumCorrect(classifier Classifier) int {
 // For example, its output might start like
 // "0: 50.25%, 1: 90.32%, 2: 30.15%".
 func (d DataSet) CorrectnessHistogram(classifier Classifier) string {
-	var correct [10]int
-	var total [10]int
+	n := d.numClasses()
+	correct := make([]int, n)
+	total := make([]int, n)
 	for _, sample := range d.Samples {
 		c := classifier(sample.Intensities)
 		if c == sample.Label {
@@ -132,7 +239,7 @@ func (d DataSet) CorrectnessHistogram(classifier Classifier) string {
 		total[sample.Label]++
 	}
 
-	histogramParts := make([]string, 10)
+	histogramParts := make([]string, n)
 	for i := range histogramParts {
 		histogramParts[i] = fmt.Sprintf("%d: %0.2f%%", i,
 			100*float64(correct[i])/float64(total[i]))
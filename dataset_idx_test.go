@@ -0,0 +1,161 @@
+package mnist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// buildIDXImages encodes a minimal IDX3 image file: a
+// 4-byte (ignored) magic, big-endian count/width/height,
+// then count*width*height raw pixel bytes.
+func buildIDXImages(width, height int, images [][]byte) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0, 0, 0, 0})
+	binary.Write(&buf, binary.BigEndian, uint32(len(images)))
+	binary.Write(&buf, binary.BigEndian, uint32(width))
+	binary.Write(&buf, binary.BigEndian, uint32(height))
+	for _, img := range images {
+		buf.Write(img)
+	}
+	return buf.Bytes()
+}
+
+// buildIDXLabels encodes a minimal IDX1 label file: an
+// 8-byte (ignored) magic+count header, then one byte per
+// label.
+func buildIDXLabels(labels []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0})
+	buf.Write(labels)
+	return buf.Bytes()
+}
+
+func TestReadIDXImages(t *testing.T) {
+	data := buildIDXImages(2, 2, [][]byte{
+		{0, 255, 0, 255},
+		{128, 128, 128, 128},
+	})
+
+	d, err := ReadIDXImages(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.Width != 2 || d.Height != 2 {
+		t.Fatalf("expected 2x2 dimensions, got %dx%d", d.Width, d.Height)
+	}
+	if len(d.Samples) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(d.Samples))
+	}
+	if d.NumClasses != 10 {
+		t.Errorf("expected NumClasses 10, got %d", d.NumClasses)
+	}
+
+	expected := []float64{0, 1, 0, 1}
+	for i, v := range expected {
+		if d.Samples[0].Intensities[i] != v {
+			t.Errorf("sample 0 intensity %d: expected %f, got %f", i, v, d.Samples[0].Intensities[i])
+		}
+	}
+	for _, v := range d.Samples[1].Intensities {
+		if v != 128.0/255.0 {
+			t.Errorf("sample 1 intensity: expected %f, got %f", 128.0/255.0, v)
+		}
+	}
+}
+
+func TestReadIDXLabels(t *testing.T) {
+	data := buildIDXLabels([]byte{3, 7})
+	labels, err := ReadIDXLabels(bytes.NewReader(data), 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(labels) != 2 || labels[0] != 3 || labels[1] != 7 {
+		t.Errorf("expected [3 7], got %v", labels)
+	}
+}
+
+func TestLoadDataSet(t *testing.T) {
+	imagesData := buildIDXImages(1, 1, [][]byte{{0}, {255}, {0}})
+	labelsData := buildIDXLabels([]byte{0, 4, 2})
+
+	for _, gzipped := range []bool{false, true} {
+		dir := t.TempDir()
+		imagesPath := filepath.Join(dir, "images-idx3-ubyte")
+		labelsPath := filepath.Join(dir, "labels-idx1-ubyte")
+		writeData := imagesData
+		writeLabels := labelsData
+		if gzipped {
+			imagesPath += ".gz"
+			labelsPath += ".gz"
+			writeData = gzipBytes(t, imagesData)
+			writeLabels = gzipBytes(t, labelsData)
+		}
+		if err := ioutil.WriteFile(imagesPath, writeData, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(labelsPath, writeLabels, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		d, err := LoadDataSet(imagesPath, labelsPath)
+		if err != nil {
+			t.Fatalf("gzipped=%v: unexpected error: %s", gzipped, err)
+		}
+		if len(d.Samples) != 3 {
+			t.Fatalf("gzipped=%v: expected 3 samples, got %d", gzipped, len(d.Samples))
+		}
+
+		expectedLabels := []int{0, 4, 2}
+		for i, l := range expectedLabels {
+			if d.Samples[i].Label != l {
+				t.Errorf("gzipped=%v: sample %d: expected label %d, got %d", gzipped, i, l, d.Samples[i].Label)
+			}
+		}
+
+		// NumClasses should be one more than the largest label seen,
+		// not hard-coded to 10.
+		if d.NumClasses != 5 {
+			t.Errorf("gzipped=%v: expected NumClasses 5, got %d", gzipped, d.NumClasses)
+		}
+	}
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestNumClassesGeneralization(t *testing.T) {
+	d := DataSet{
+		NumClasses: 3,
+		Samples: []Sample{
+			{Intensities: []float64{0}, Label: 0},
+			{Intensities: []float64{0}, Label: 1},
+			{Intensities: []float64{0}, Label: 2},
+		},
+	}
+
+	for _, vec := range d.LabelVectors() {
+		if len(vec) != 3 {
+			t.Fatalf("expected label vectors of length 3, got %d", len(vec))
+		}
+	}
+
+	histogram := d.CorrectnessHistogram(func(data []float64) int { return 0 })
+	// Only labels 0, 1, and 2 (not the hard-coded 0..9) should appear.
+	if want := "0: 100.00%, 1: 0.00%, 2: 0.00%"; histogram != want {
+		t.Errorf("expected %q, got %q", want, histogram)
+	}
+}
@@ -0,0 +1,392 @@
+// Package hnsw implements a Hierarchical Navigable
+// Small World graph for approximate nearest-neighbor
+// search over the intensity vectors of a mnist.DataSet.
+package hnsw
+
+import (
+	"container/heap"
+	"encoding/gob"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/unixpickle/mnist"
+)
+
+// An HNSWConfig configures the construction of an
+// HNSWIndex.
+type HNSWConfig struct {
+	// M is the maximum number of neighbors kept per
+	// node on layers above the base layer. The base
+	// layer keeps up to 2*M neighbors.
+	M int
+
+	// Ef is the size of the dynamic candidate list used
+	// while building the graph. Larger values produce a
+	// higher-quality graph at the cost of build time.
+	Ef int
+
+	// Metric measures distance between two intensity
+	// vectors. If nil, mnist's Euclidean-style distance
+	// is used.
+	Metric func(a, b []float64) float64
+}
+
+// DefaultHNSWConfig returns the HNSWConfig used when
+// BuildHNSW is called with a zero-value HNSWConfig.
+func DefaultHNSWConfig() HNSWConfig {
+	return HNSWConfig{M: 16, Ef: 200, Metric: euclidean}
+}
+
+func euclidean(a, b []float64) float64 {
+	var sum float64
+	for i, x := range a {
+		d := x - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// A Neighbor is one result of a Search.
+type Neighbor struct {
+	Index    int
+	Label    int
+	Distance float64
+}
+
+type hnswNode struct {
+	Vector    []float64
+	Label     int
+	Neighbors [][]int
+}
+
+// An HNSWIndex is a searchable HNSW graph built over
+// the samples of a mnist.DataSet.
+type HNSWIndex struct {
+	Config      HNSWConfig
+	Nodes       []hnswNode
+	EntryPoint  int
+	MaxLevel    int
+	LevelFactor float64
+}
+
+// BuildHNSW constructs an HNSWIndex over the samples of
+// d using cfg. Zero-valued fields of cfg (M, Ef) fall
+// back to the values in DefaultHNSWConfig.
+func BuildHNSW(d mnist.DataSet, cfg HNSWConfig) *HNSWIndex {
+	def := DefaultHNSWConfig()
+	if cfg.M == 0 {
+		cfg.M = def.M
+	}
+	if cfg.Ef == 0 {
+		cfg.Ef = def.Ef
+	}
+	if cfg.Metric == nil {
+		cfg.Metric = def.Metric
+	}
+
+	idx := &HNSWIndex{
+		Config:      cfg,
+		LevelFactor: 1 / math.Log(float64(cfg.M)),
+		EntryPoint:  -1,
+		MaxLevel:    -1,
+	}
+
+	for i, sample := range d.Samples {
+		idx.insert(i, sample.Intensities, sample.Label)
+	}
+
+	return idx
+}
+
+func (idx *HNSWIndex) maxNeighbors(level int) int {
+	if level == 0 {
+		return 2 * idx.Config.M
+	}
+	return idx.Config.M
+}
+
+func (idx *HNSWIndex) randomLevel() int {
+	u := rand.Float64()
+	for u == 0 {
+		u = rand.Float64()
+	}
+	return int(math.Floor(-math.Log(u) * idx.LevelFactor))
+}
+
+func (idx *HNSWIndex) insert(id int, vector []float64, label int) {
+	level := idx.randomLevel()
+	node := hnswNode{
+		Vector:    vector,
+		Label:     label,
+		Neighbors: make([][]int, level+1),
+	}
+	idx.Nodes = append(idx.Nodes, node)
+
+	if idx.EntryPoint == -1 {
+		idx.EntryPoint = id
+		idx.MaxLevel = level
+		return
+	}
+
+	entry := idx.EntryPoint
+	for l := idx.MaxLevel; l > level; l-- {
+		entry = idx.greedyClosest(entry, vector, l)
+	}
+
+	for l := min(level, idx.MaxLevel); l >= 0; l-- {
+		candidates := idx.searchLayer(vector, entry, idx.Config.Ef, l)
+		neighbors := idx.selectNeighbors(vector, candidates, idx.maxNeighbors(l))
+		idx.Nodes[id].Neighbors[l] = neighbors
+
+		for _, n := range neighbors {
+			idx.linkTo(n, id, l)
+		}
+		if len(candidates) > 0 {
+			entry = candidates[0].id
+		}
+	}
+
+	if level > idx.MaxLevel {
+		idx.MaxLevel = level
+		idx.EntryPoint = id
+	}
+}
+
+// linkTo adds an edge from node "to" back to "from" at
+// layer l, completing a bidirectional link, and prunes
+// "to"'s neighbor list if it grows past its cap.
+func (idx *HNSWIndex) linkTo(to, from, l int) {
+	for len(idx.Nodes[to].Neighbors) <= l {
+		idx.Nodes[to].Neighbors = append(idx.Nodes[to].Neighbors, nil)
+	}
+	neighbors := append(idx.Nodes[to].Neighbors[l], from)
+	max := idx.maxNeighbors(l)
+	if len(neighbors) > max {
+		cands := make([]hnswCandidate, len(neighbors))
+		for i, n := range neighbors {
+			cands[i] = hnswCandidate{id: n, dist: idx.Config.Metric(idx.Nodes[to].Vector, idx.Nodes[n].Vector)}
+		}
+		neighbors = idx.selectNeighbors(idx.Nodes[to].Vector, cands, max)
+	}
+	idx.Nodes[to].Neighbors[l] = neighbors
+}
+
+// greedyClosest walks from entry to the locally closest
+// node to query at layer l, using a simple hill climb.
+func (idx *HNSWIndex) greedyClosest(entry int, query []float64, l int) int {
+	current := entry
+	currentDist := idx.Config.Metric(query, idx.Nodes[current].Vector)
+	for {
+		improved := false
+		for _, n := range idx.layerNeighbors(current, l) {
+			d := idx.Config.Metric(query, idx.Nodes[n].Vector)
+			if d < currentDist {
+				current = n
+				currentDist = d
+				improved = true
+			}
+		}
+		if !improved {
+			return current
+		}
+	}
+}
+
+func (idx *HNSWIndex) layerNeighbors(id, l int) []int {
+	if l >= len(idx.Nodes[id].Neighbors) {
+		return nil
+	}
+	return idx.Nodes[id].Neighbors[l]
+}
+
+type hnswCandidate struct {
+	id   int
+	dist float64
+}
+
+// searchLayer performs a bounded best-first search for
+// the ef candidates closest to query at layer l,
+// starting from entry. Results are sorted nearest
+// first.
+func (idx *HNSWIndex) searchLayer(query []float64, entry int, ef, l int) []hnswCandidate {
+	visited := map[int]bool{entry: true}
+	entryDist := idx.Config.Metric(query, idx.Nodes[entry].Vector)
+
+	candidates := &minCandHeap{{id: entry, dist: entryDist}}
+	results := &maxCandHeap{{id: entry, dist: entryDist}}
+
+	for candidates.Len() > 0 {
+		nearest := (*candidates)[0]
+		if nearest.dist > (*results)[0].dist && results.Len() >= ef {
+			break
+		}
+		heap.Pop(candidates)
+
+		for _, n := range idx.layerNeighbors(nearest.id, l) {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			d := idx.Config.Metric(query, idx.Nodes[n].Vector)
+			if results.Len() < ef || d < (*results)[0].dist {
+				heap.Push(candidates, hnswCandidate{id: n, dist: d})
+				heap.Push(results, hnswCandidate{id: n, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := make([]hnswCandidate, results.Len())
+	copy(out, *results)
+	sortCandidates(out)
+	return out
+}
+
+// selectNeighbors implements the "heuristic" neighbor
+// selection from the HNSW paper: a candidate is kept
+// only if it is closer to the query than to every
+// already-selected neighbor.
+func (idx *HNSWIndex) selectNeighbors(query []float64, candidates []hnswCandidate, max int) []int {
+	sorted := make([]hnswCandidate, len(candidates))
+	copy(sorted, candidates)
+	sortCandidates(sorted)
+
+	var selected []hnswCandidate
+	for _, c := range sorted {
+		if len(selected) >= max {
+			break
+		}
+		good := true
+		for _, s := range selected {
+			if idx.Config.Metric(idx.Nodes[c.id].Vector, idx.Nodes[s.id].Vector) < c.dist {
+				good = false
+				break
+			}
+		}
+		if good {
+			selected = append(selected, c)
+		}
+	}
+
+	ids := make([]int, len(selected))
+	for i, s := range selected {
+		ids[i] = s.id
+	}
+	return ids
+}
+
+// Search finds the k approximate nearest neighbors of
+// query, using a candidate list of size ef during the
+// base-layer search.
+func (idx *HNSWIndex) Search(query []float64, k, ef int) []Neighbor {
+	if idx.EntryPoint == -1 {
+		return nil
+	}
+
+	entry := idx.EntryPoint
+	for l := idx.MaxLevel; l > 0; l-- {
+		entry = idx.greedyClosest(entry, query, l)
+	}
+
+	candidates := idx.searchLayer(query, entry, ef, 0)
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	results := make([]Neighbor, len(candidates))
+	for i, c := range candidates {
+		results[i] = Neighbor{
+			Index:    c.id,
+			Label:    idx.Nodes[c.id].Label,
+			Distance: c.dist,
+		}
+	}
+	return results
+}
+
+// NewClassifier creates a mnist.Classifier backed by idx,
+// labeling a query vector according to the majority label
+// among its k approximate nearest neighbors, found via
+// Search with the given ef. This lets idx stand in for
+// mnist.NewKNNClassifier's exact search with a sub-linear
+// approximate one.
+func (idx *HNSWIndex) NewClassifier(k, ef int) mnist.Classifier {
+	return func(data []float64) int {
+		neighbors := idx.Search(data, k, ef)
+		knnNeighbors := make([]mnist.KNNNeighbor, len(neighbors))
+		for i, n := range neighbors {
+			knnNeighbors[i] = mnist.KNNNeighbor{Label: n.Label, Distance: n.Distance}
+		}
+		return mnist.MajorityLabel(knnNeighbors)
+	}
+}
+
+// Save writes the index to w using encoding/gob, so
+// that it can be reconstructed with Load without
+// rebuilding the graph.
+func (idx *HNSWIndex) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(idx)
+}
+
+// Load reads an HNSWIndex previously written by Save.
+//
+// Config.Metric is a function and is therefore never
+// written by Save nor restored by gob, so Load re-applies
+// DefaultHNSWConfig's Metric. If the index was built with
+// a custom Metric, set idx.Config.Metric on the returned
+// index before calling Search.
+func Load(r io.Reader) (*HNSWIndex, error) {
+	var idx HNSWIndex
+	if err := gob.NewDecoder(r).Decode(&idx); err != nil {
+		return nil, err
+	}
+	if idx.Config.Metric == nil {
+		idx.Config.Metric = DefaultHNSWConfig().Metric
+	}
+	return &idx, nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func sortCandidates(c []hnswCandidate) {
+	sort.Slice(c, func(i, j int) bool { return c[i].dist < c[j].dist })
+}
+
+// minCandHeap is a min-heap of hnswCandidate by dist.
+type minCandHeap []hnswCandidate
+
+func (h minCandHeap) Len() int            { return len(h) }
+func (h minCandHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h minCandHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minCandHeap) Push(x interface{}) { *h = append(*h, x.(hnswCandidate)) }
+func (h *minCandHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// maxCandHeap is a max-heap of hnswCandidate by dist.
+type maxCandHeap []hnswCandidate
+
+func (h maxCandHeap) Len() int            { return len(h) }
+func (h maxCandHeap) Less(i, j int) bool  { return h[i].dist > h[j].dist }
+func (h maxCandHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxCandHeap) Push(x interface{}) { *h = append(*h, x.(hnswCandidate)) }
+func (h *maxCandHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
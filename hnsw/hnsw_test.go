@@ -0,0 +1,90 @@
+package hnsw
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/unixpickle/mnist"
+)
+
+func testDataSet() mnist.DataSet {
+	var d mnist.DataSet
+	d.NumClasses = 2
+	for i := 0; i < 50; i++ {
+		d.Samples = append(d.Samples, mnist.Sample{
+			Intensities: []float64{float64(i), 0},
+			Label:       0,
+		})
+	}
+	for i := 0; i < 50; i++ {
+		d.Samples = append(d.Samples, mnist.Sample{
+			Intensities: []float64{float64(i), 100},
+			Label:       1,
+		})
+	}
+	return d
+}
+
+func TestSearchFindsNearest(t *testing.T) {
+	d := testDataSet()
+	idx := BuildHNSW(d, HNSWConfig{})
+
+	neighbors := idx.Search([]float64{5, 0}, 5, 50)
+	if len(neighbors) != 5 {
+		t.Fatalf("expected 5 neighbors, got %d", len(neighbors))
+	}
+	for _, n := range neighbors {
+		if n.Label != 0 {
+			t.Errorf("expected all neighbors to have label 0, got %d", n.Label)
+		}
+	}
+}
+
+func TestSearchOtherCluster(t *testing.T) {
+	d := testDataSet()
+	idx := BuildHNSW(d, HNSWConfig{})
+
+	neighbors := idx.Search([]float64{5, 100}, 5, 50)
+	for _, n := range neighbors {
+		if n.Label != 1 {
+			t.Errorf("expected all neighbors to have label 1, got %d", n.Label)
+		}
+	}
+}
+
+func TestSaveLoadRestoresMetric(t *testing.T) {
+	d := testDataSet()
+	idx := BuildHNSW(d, HNSWConfig{})
+
+	var buf bytes.Buffer
+	if err := idx.Save(&buf); err != nil {
+		t.Fatalf("save failed: %s", err)
+	}
+
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("load failed: %s", err)
+	}
+	if loaded.Config.Metric == nil {
+		t.Fatal("expected Metric to be restored after Load")
+	}
+
+	// This would nil-panic before Config.Metric was restored.
+	neighbors := loaded.Search([]float64{5, 0}, 5, 50)
+	if len(neighbors) != 5 {
+		t.Fatalf("expected 5 neighbors after load, got %d", len(neighbors))
+	}
+}
+
+func TestNewClassifier(t *testing.T) {
+	d := testDataSet()
+	idx := BuildHNSW(d, HNSWConfig{})
+	classifier := idx.NewClassifier(5, 50)
+
+	if l := classifier([]float64{5, 0}); l != 0 {
+		t.Errorf("expected label 0, got %d", l)
+	}
+	if l := classifier([]float64{5, 100}); l != 1 {
+		t.Errorf("expected label 1, got %d", l)
+	}
+}
@@ -0,0 +1,229 @@
+package mnist
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/tiff"
+)
+
+// sampleWidth and sampleHeight are the dimensions used
+// by SampleFromImage and SampleToImage, matching the
+// original MNIST corpus.
+const (
+	sampleWidth  = 28
+	sampleHeight = 28
+	sampleInner  = 20
+)
+
+// LoadSample reads an image file (PNG, JPEG, or TIFF,
+// selected by extension) and converts it to a Sample
+// via SampleFromImage.
+//
+// The returned Sample's Label is always 0, since the
+// image's file has no way of conveying it.
+func LoadSample(path string) (Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Sample{}, err
+	}
+	defer f.Close()
+
+	var img image.Image
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		img, err = png.Decode(f)
+	case ".jpg", ".jpeg":
+		img, err = jpeg.Decode(f)
+	case ".tif", ".tiff":
+		img, err = tiff.Decode(f)
+	default:
+		return Sample{}, errors.New("unsupported image extension: " + filepath.Ext(path))
+	}
+	if err != nil {
+		return Sample{}, err
+	}
+
+	return SampleFromImage(img), nil
+}
+
+// SampleFromImage converts img into a Sample using the
+// same preprocessing steps used to produce the original
+// MNIST corpus: the image is converted to grayscale,
+// inverted if it appears to be light-on-dark, cropped to
+// its non-background bounding box, rescaled (preserving
+// aspect ratio) to fit inside a 20x20 box, and centered
+// by center of mass within a 28x28 field.
+func SampleFromImage(img image.Image) Sample {
+	gray, w, h := grayscaleIntensities(img)
+	if meanIntensity(gray) > 0.5 {
+		invertIntensities(gray)
+	}
+
+	cropped, cw, ch := cropToBoundingBox(gray, w, h)
+	scaled, sw, sh := fitToBox(cropped, cw, ch, sampleInner)
+	centered := centerByMass(scaled, sw, sh, sampleWidth, sampleHeight)
+
+	return Sample{Intensities: centered}
+}
+
+// SampleToImage renders s as a grayscale image.Image,
+// scaling its intensities (assumed to be sampleWidth by
+// sampleHeight) to fill a width by height image.
+func SampleToImage(s Sample, width, height int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		sy := y * sampleHeight / height
+		for x := 0; x < width; x++ {
+			sx := x * sampleWidth / width
+			pixel := s.Intensities[sy*sampleWidth+sx]
+			img.SetGray(x, y, color.Gray{Y: 0xff - uint8(pixel*0xff)})
+		}
+	}
+	return img
+}
+
+// grayscaleIntensities converts img to a row-major
+// slice of intensities in [0, 1], where 1 is black.
+func grayscaleIntensities(img image.Image) ([]float64, int, int) {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	res := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			gray := color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray)
+			res[y*w+x] = 1 - float64(gray.Y)/0xff
+		}
+	}
+	return res, w, h
+}
+
+func meanIntensity(intensities []float64) float64 {
+	var sum float64
+	for _, x := range intensities {
+		sum += x
+	}
+	return sum / float64(len(intensities))
+}
+
+func invertIntensities(intensities []float64) {
+	for i, x := range intensities {
+		intensities[i] = 1 - x
+	}
+}
+
+// cropToBoundingBox crops a w by h intensity grid to the
+// smallest rectangle containing every non-background
+// (i.e. non-zero) pixel.
+func cropToBoundingBox(intensities []float64, w, h int) ([]float64, int, int) {
+	minX, minY, maxX, maxY := w, h, -1, -1
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if intensities[y*w+x] > 0 {
+				if x < minX {
+					minX = x
+				}
+				if x > maxX {
+					maxX = x
+				}
+				if y < minY {
+					minY = y
+				}
+				if y > maxY {
+					maxY = y
+				}
+			}
+		}
+	}
+	if maxX < minX || maxY < minY {
+		return []float64{0}, 1, 1
+	}
+
+	cw, ch := maxX-minX+1, maxY-minY+1
+	cropped := make([]float64, cw*ch)
+	for y := 0; y < ch; y++ {
+		for x := 0; x < cw; x++ {
+			cropped[y*cw+x] = intensities[(y+minY)*w+(x+minX)]
+		}
+	}
+	return cropped, cw, ch
+}
+
+// fitToBox rescales a w by h intensity grid, preserving
+// its aspect ratio, so that its larger dimension equals
+// box.
+func fitToBox(intensities []float64, w, h, box int) ([]float64, int, int) {
+	var newW, newH int
+	if w > h {
+		newW = box
+		newH = h * box / w
+	} else {
+		newH = box
+		newW = w * box / h
+	}
+	if newW < 1 {
+		newW = 1
+	}
+	if newH < 1 {
+		newH = 1
+	}
+
+	scaled := make([]float64, newW*newH)
+	for y := 0; y < newH; y++ {
+		sy := y * h / newH
+		for x := 0; x < newW; x++ {
+			sx := x * w / newW
+			scaled[y*newW+x] = intensities[sy*w+sx]
+		}
+	}
+	return scaled, newW, newH
+}
+
+// centerByMass places a w by h intensity grid into an
+// outW by outH field, positioned so that the grid's
+// center of mass aligns with the center of the field.
+func centerByMass(intensities []float64, w, h, outW, outH int) []float64 {
+	var sumX, sumY, sumMass float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			mass := intensities[y*w+x]
+			sumX += float64(x) * mass
+			sumY += float64(y) * mass
+			sumMass += mass
+		}
+	}
+
+	var centerX, centerY float64
+	if sumMass > 0 {
+		centerX = sumX / sumMass
+		centerY = sumY / sumMass
+	} else {
+		centerX = float64(w) / 2
+		centerY = float64(h) / 2
+	}
+
+	offsetX := int(float64(outW)/2 - centerX)
+	offsetY := int(float64(outH)/2 - centerY)
+
+	out := make([]float64, outW*outH)
+	for y := 0; y < h; y++ {
+		oy := y + offsetY
+		if oy < 0 || oy >= outH {
+			continue
+		}
+		for x := 0; x < w; x++ {
+			ox := x + offsetX
+			if ox < 0 || ox >= outW {
+				continue
+			}
+			out[oy*outW+ox] = intensities[y*w+x]
+		}
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package mnist
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// buildDigitImage draws a simple plus-shaped digit in
+// foreground over a background of background, both
+// given as grayscale values in [0, 0xff].
+func buildDigitImage(background, foreground uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			img.SetGray(x, y, color.Gray{Y: background})
+		}
+	}
+	for i := 2; i < 8; i++ {
+		img.SetGray(i, 5, color.Gray{Y: foreground})
+		img.SetGray(5, i, color.Gray{Y: foreground})
+	}
+	return img
+}
+
+func TestMeanIntensity(t *testing.T) {
+	if m := meanIntensity([]float64{0, 1}); m != 0.5 {
+		t.Errorf("expected mean 0.5, got %f", m)
+	}
+}
+
+func TestInvertIntensities(t *testing.T) {
+	vals := []float64{0, 0.25, 1}
+	invertIntensities(vals)
+	expected := []float64{1, 0.75, 0}
+	for i, v := range expected {
+		if vals[i] != v {
+			t.Errorf("index %d: expected %f, got %f", i, v, vals[i])
+		}
+	}
+}
+
+func TestCropToBoundingBox(t *testing.T) {
+	// A 4x4 grid with a single non-background pixel at (1, 2).
+	grid := make([]float64, 16)
+	grid[2*4+1] = 1
+
+	cropped, w, h := cropToBoundingBox(grid, 4, 4)
+	if w != 1 || h != 1 {
+		t.Fatalf("expected a 1x1 crop, got %dx%d", w, h)
+	}
+	if cropped[0] != 1 {
+		t.Errorf("expected cropped pixel to be 1, got %f", cropped[0])
+	}
+}
+
+func TestCropToBoundingBoxEmpty(t *testing.T) {
+	grid := make([]float64, 16)
+	cropped, w, h := cropToBoundingBox(grid, 4, 4)
+	if w != 1 || h != 1 || len(cropped) != 1 {
+		t.Fatalf("expected a degenerate 1x1 crop for an empty grid, got %dx%d", w, h)
+	}
+}
+
+func TestFitToBox(t *testing.T) {
+	grid := make([]float64, 4*2)
+	scaled, w, h := fitToBox(grid, 4, 2, 20)
+	if w != 20 || h != 10 {
+		t.Fatalf("expected a 20x10 fit preserving aspect ratio, got %dx%d", w, h)
+	}
+}
+
+func TestCenterByMass(t *testing.T) {
+	// A single pixel of mass at the top-left corner of a 2x2 grid
+	// should end up centered within a larger field.
+	grid := []float64{1, 0, 0, 0}
+	out := centerByMass(grid, 2, 2, 4, 4)
+
+	var sumX, sumY, sumMass float64
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			mass := out[y*4+x]
+			sumX += float64(x) * mass
+			sumY += float64(y) * mass
+			sumMass += mass
+		}
+	}
+	if sumMass != 1 {
+		t.Fatalf("expected the pixel's mass to be preserved, got %f", sumMass)
+	}
+	if sumX/sumMass != 2 || sumY/sumMass != 2 {
+		t.Errorf("expected the pixel to be centered at (2, 2), got (%f, %f)",
+			sumX/sumMass, sumY/sumMass)
+	}
+}
+
+func TestSampleFromImageDarkOnLight(t *testing.T) {
+	// A mostly-white image with a black digit is already in the
+	// expected dark-on-light convention, so it should pass through
+	// without being inverted.
+	img := buildDigitImage(0xff, 0)
+
+	sample := SampleFromImage(img)
+	var maxIntensity float64
+	for _, v := range sample.Intensities {
+		if v > maxIntensity {
+			maxIntensity = v
+		}
+	}
+	if maxIntensity == 0 {
+		t.Fatal("expected the digit pixel to survive preprocessing with nonzero intensity")
+	}
+}
+
+func TestSampleFromImageInvertsLightOnDark(t *testing.T) {
+	// A mostly-black image with a white digit is light-on-dark, the
+	// opposite of MNIST's convention, so SampleFromImage should
+	// invert it. Once inverted, its raw intensities match those of
+	// the same digit drawn dark-on-light, so the two should produce
+	// identical Samples.
+	darkOnLight := buildDigitImage(0xff, 0)
+	lightOnDark := buildDigitImage(0, 0xff)
+
+	want := SampleFromImage(darkOnLight)
+	got := SampleFromImage(lightOnDark)
+
+	if len(got.Intensities) != len(want.Intensities) {
+		t.Fatalf("expected %d intensities, got %d", len(want.Intensities), len(got.Intensities))
+	}
+	for i, w := range want.Intensities {
+		if math.Abs(got.Intensities[i]-w) > 1e-9 {
+			t.Fatalf("intensity %d: expected %f (inverted to match dark-on-light), got %f",
+				i, w, got.Intensities[i])
+		}
+	}
+}
+
+func TestSampleToImageRoundTrip(t *testing.T) {
+	sample := Sample{Intensities: make([]float64, sampleWidth*sampleHeight)}
+	sample.Intensities[0] = 1
+	img := SampleToImage(sample, sampleWidth, sampleHeight)
+	if img.Bounds().Dx() != sampleWidth || img.Bounds().Dy() != sampleHeight {
+		t.Fatalf("expected a %dx%d image, got %dx%d", sampleWidth, sampleHeight,
+			img.Bounds().Dx(), img.Bounds().Dy())
+	}
+	back, _, _ := grayscaleIntensities(img)
+	for i, v := range sample.Intensities {
+		if back[i] != v {
+			t.Fatalf("intensity %d: expected %f after round trip, got %f", i, v, back[i])
+		}
+	}
+}
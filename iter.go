@@ -0,0 +1,166 @@
+package mnist
+
+import (
+	"math/rand"
+
+	"github.com/unixpickle/anynet/anyff"
+	"github.com/unixpickle/anyvec"
+)
+
+// An IterOption configures the behavior of a BatchIter
+// produced by DataSet.Iter.
+type IterOption func(*iterConfig)
+
+type iterConfig struct {
+	shuffle bool
+	seed    int64
+	drop    bool
+	creator anyvec.Creator
+}
+
+// WithShuffle causes the iterator to shuffle the order
+// of samples, using seed to initialize the random
+// source, before each epoch.
+func WithShuffle(seed int64) IterOption {
+	return func(c *iterConfig) {
+		c.shuffle = true
+		c.seed = seed
+	}
+}
+
+// WithDrop causes the iterator to drop the final,
+// partial batch of an epoch whenever the number of
+// remaining samples is less than the batch size.
+func WithDrop(drop bool) IterOption {
+	return func(c *iterConfig) {
+		c.drop = drop
+	}
+}
+
+// WithDevice causes batches produced by Next to carry
+// an anyvec.Batch (built using creator) in addition to
+// the raw intensity/label slices.
+func WithDevice(creator anyvec.Creator) IterOption {
+	return func(c *iterConfig) {
+		c.creator = creator
+	}
+}
+
+// A Batch is one mini-batch produced by a BatchIter.
+type Batch struct {
+	// Intensities and Labels hold the raw samples in
+	// the batch, in order.
+	Intensities [][]float64
+	Labels      []int
+
+	// Anyvec is non-nil if the BatchIter was created
+	// with WithDevice, in which case it holds the same
+	// samples as an anyff.SampleList.
+	Anyvec anyff.SampleList
+}
+
+// A BatchIter produces an endless stream of
+// mini-batches from a DataSet, looping back to the
+// beginning once every sample has been visited.
+type BatchIter struct {
+	dataSet   DataSet
+	batchSize int
+	config    iterConfig
+	rand      *rand.Rand
+
+	order []int
+	pos   int
+	epoch int
+}
+
+// Iter creates a BatchIter over d, yielding batches of
+// batchSize samples at a time.
+//
+// By default, samples are visited in dataset order and
+// the final, partial batch of an epoch is included. Use
+// WithShuffle, WithDrop, and WithDevice to change this.
+func (d DataSet) Iter(batchSize int, opts ...IterOption) *BatchIter {
+	b := &BatchIter{
+		dataSet:   d,
+		batchSize: batchSize,
+	}
+	for _, opt := range opts {
+		opt(&b.config)
+	}
+	if b.config.shuffle {
+		b.rand = rand.New(rand.NewSource(b.config.seed))
+	}
+	b.resetOrder()
+	return b
+}
+
+// Epoch returns the current epoch, starting at 0 and
+// incrementing every time the iterator wraps around to
+// the beginning of the DataSet.
+func (b *BatchIter) Epoch() int {
+	return b.epoch
+}
+
+// Next returns the next mini-batch, wrapping around to
+// the beginning of the DataSet (and incrementing Epoch)
+// as needed.
+func (b *BatchIter) Next() *Batch {
+	if b.pos >= len(b.order) || (b.config.drop && b.pos+b.batchSize > len(b.order)) {
+		b.epoch++
+		b.resetOrder()
+	}
+
+	end := b.pos + b.batchSize
+	if end > len(b.order) {
+		end = len(b.order)
+	}
+	idxs := b.order[b.pos:end]
+	b.pos = end
+
+	return b.makeBatch(idxs)
+}
+
+func (b *BatchIter) resetOrder() {
+	n := len(b.dataSet.Samples)
+	if b.order == nil {
+		b.order = make([]int, n)
+		for i := range b.order {
+			b.order[i] = i
+		}
+	}
+	if b.config.shuffle {
+		b.rand.Shuffle(n, func(i, j int) {
+			b.order[i], b.order[j] = b.order[j], b.order[i]
+		})
+	}
+	b.pos = 0
+}
+
+func (b *BatchIter) makeBatch(idxs []int) *Batch {
+	batch := &Batch{
+		Intensities: make([][]float64, len(idxs)),
+		Labels:      make([]int, len(idxs)),
+	}
+	for i, idx := range idxs {
+		sample := b.dataSet.Samples[idx]
+		batch.Intensities[i] = sample.Intensities
+		batch.Labels[i] = sample.Label
+	}
+
+	if c := b.config.creator; c != nil {
+		var list anyff.SliceSampleList
+		numClasses := b.dataSet.numClasses()
+		for _, idx := range idxs {
+			sample := b.dataSet.Samples[idx]
+			labelVec := make([]float64, numClasses)
+			labelVec[sample.Label] = 1
+			list = append(list, &anyff.Sample{
+				Input:  c.MakeVectorData(c.MakeNumericList(sample.Intensities)),
+				Output: c.MakeVectorData(c.MakeNumericList(labelVec)),
+			})
+		}
+		batch.Anyvec = list
+	}
+
+	return batch
+}
@@ -0,0 +1,86 @@
+package mnist
+
+import "testing"
+
+func testDataSet(n int) DataSet {
+	var d DataSet
+	d.NumClasses = 10
+	for i := 0; i < n; i++ {
+		d.Samples = append(d.Samples, Sample{Intensities: []float64{float64(i)}, Label: i % 10})
+	}
+	return d
+}
+
+func TestBatchIterOrder(t *testing.T) {
+	d := testDataSet(5)
+	iter := d.Iter(2)
+
+	var labels []int
+	for i := 0; i < 3; i++ {
+		batch := iter.Next()
+		labels = append(labels, batch.Labels...)
+	}
+	expected := []int{0, 1, 2, 3, 4}
+	if len(labels) != len(expected) {
+		t.Fatalf("expected %d labels, got %d", len(expected), len(labels))
+	}
+	for i, l := range expected {
+		if labels[i] != l {
+			t.Errorf("label %d: expected %d, got %d", i, l, labels[i])
+		}
+	}
+}
+
+func TestBatchIterEpoch(t *testing.T) {
+	d := testDataSet(4)
+	iter := d.Iter(3)
+
+	if iter.Epoch() != 0 {
+		t.Fatalf("expected epoch 0, got %d", iter.Epoch())
+	}
+	iter.Next()
+	if iter.Epoch() != 0 {
+		t.Fatalf("expected epoch 0 after first batch, got %d", iter.Epoch())
+	}
+	iter.Next()
+	if iter.Epoch() != 1 {
+		t.Fatalf("expected epoch 1 after wrapping, got %d", iter.Epoch())
+	}
+}
+
+func TestBatchIterDrop(t *testing.T) {
+	d := testDataSet(5)
+	iter := d.Iter(2, WithDrop(true))
+
+	batch := iter.Next()
+	if len(batch.Labels) != 2 {
+		t.Fatalf("expected batch of size 2, got %d", len(batch.Labels))
+	}
+	batch = iter.Next()
+	if len(batch.Labels) != 2 {
+		t.Fatalf("expected batch of size 2, got %d", len(batch.Labels))
+	}
+	// The 5th sample is a partial batch and should be dropped,
+	// wrapping immediately to a fresh epoch.
+	batch = iter.Next()
+	if len(batch.Labels) != 2 {
+		t.Fatalf("expected batch of size 2 after drop, got %d", len(batch.Labels))
+	}
+	if iter.Epoch() != 1 {
+		t.Fatalf("expected epoch 1 after dropping partial batch, got %d", iter.Epoch())
+	}
+}
+
+func TestBatchIterShuffleDeterministic(t *testing.T) {
+	d := testDataSet(10)
+	iter1 := d.Iter(10, WithShuffle(42))
+	iter2 := d.Iter(10, WithShuffle(42))
+
+	labels1 := iter1.Next().Labels
+	labels2 := iter2.Next().Labels
+	for i := range labels1 {
+		if labels1[i] != labels2[i] {
+			t.Fatalf("shuffles with the same seed diverged at index %d", i)
+		}
+	}
+}
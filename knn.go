@@ -0,0 +1,189 @@
+package mnist
+
+import (
+	"container/heap"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// A Metric measures the distance between two intensity
+// vectors of equal length. Smaller values indicate
+// more similar vectors.
+type Metric func(a, b []float64) float64
+
+// EuclideanMetric is a Metric based on Euclidean
+// distance.
+func EuclideanMetric(a, b []float64) float64 {
+	var sum float64
+	for i, x := range a {
+		d := x - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// L1Metric is a Metric based on the sum of absolute
+// differences between components.
+func L1Metric(a, b []float64) float64 {
+	var sum float64
+	for i, x := range a {
+		sum += math.Abs(x - b[i])
+	}
+	return sum
+}
+
+// CosineMetric is a Metric based on 1 minus the cosine
+// similarity between two vectors, so that smaller
+// values indicate more similar vectors.
+func CosineMetric(a, b []float64) float64 {
+	var dot, magA, magB float64
+	for i, x := range a {
+		dot += x * b[i]
+		magA += x * x
+		magB += b[i] * b[i]
+	}
+	if magA == 0 || magB == 0 {
+		return 1
+	}
+	return 1 - dot/math.Sqrt(magA*magB)
+}
+
+// A KNNNeighbor is one of the k nearest neighbors found
+// by KNNPredictTopK.
+type KNNNeighbor struct {
+	Label    int
+	Distance float64
+}
+
+// NewKNNClassifier creates a Classifier which labels a
+// query vector according to the majority label among
+// its k nearest neighbors in d, as measured by metric.
+//
+// Ties in the majority vote are broken in favor of the
+// label belonging to the nearest neighbor.
+func NewKNNClassifier(d DataSet, k int, metric Metric) Classifier {
+	return func(data []float64) int {
+		neighbors := knnNearest(d, data, k, metric)
+		return MajorityLabel(neighbors)
+	}
+}
+
+// KNNPredictTopK returns the k nearest neighbors of
+// data in d, as measured by metric, sorted from
+// nearest to farthest.
+func KNNPredictTopK(d DataSet, data []float64, k int, metric Metric) []KNNNeighbor {
+	return knnNearest(d, data, k, metric)
+}
+
+// knnNearest finds the k samples in d closest to data,
+// sorted from nearest to farthest. It parallelizes the
+// distance computation across runtime.NumCPU goroutines
+// and merges the per-worker results with a max-heap of
+// size k.
+func knnNearest(d DataSet, data []float64, k int, metric Metric) []KNNNeighbor {
+	numWorkers := runtime.NumCPU()
+	if numWorkers > len(d.Samples) {
+		numWorkers = 1
+	}
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+
+	chunkResults := make([][]KNNNeighbor, numWorkers)
+	var wg sync.WaitGroup
+	chunkSize := (len(d.Samples) + numWorkers - 1) / numWorkers
+	for w := 0; w < numWorkers; w++ {
+		start := w * chunkSize
+		end := start + chunkSize
+		if end > len(d.Samples) {
+			end = len(d.Samples)
+		}
+		if start >= end {
+			continue
+		}
+		wg.Add(1)
+		go func(w, start, end int) {
+			defer wg.Done()
+			h := &knnHeap{}
+			for i := start; i < end; i++ {
+				sample := d.Samples[i]
+				dist := metric(data, sample.Intensities)
+				knnPush(h, KNNNeighbor{Label: sample.Label, Distance: dist}, k)
+			}
+			chunkResults[w] = []KNNNeighbor(*h)
+		}(w, start, end)
+	}
+	wg.Wait()
+
+	h := &knnHeap{}
+	for _, chunk := range chunkResults {
+		for _, n := range chunk {
+			knnPush(h, n, k)
+		}
+	}
+
+	result := []KNNNeighbor(*h)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Distance < result[j].Distance
+	})
+	return result
+}
+
+// MajorityLabel returns the majority label among
+// neighbors, breaking ties in favor of the label whose
+// own nearest neighbor is closest. It is exported so that
+// other approximate nearest-neighbor implementations
+// (such as the hnsw subpackage) can reuse the same
+// majority-vote logic as NewKNNClassifier.
+func MajorityLabel(neighbors []KNNNeighbor) int {
+	counts := map[int]int{}
+	minDist := map[int]float64{}
+	for _, n := range neighbors {
+		counts[n.Label]++
+		if d, ok := minDist[n.Label]; !ok || n.Distance < d {
+			minDist[n.Label] = n.Distance
+		}
+	}
+
+	best := -1
+	bestCount := -1
+	bestDist := math.Inf(1)
+	for label, count := range counts {
+		dist := minDist[label]
+		if count > bestCount || (count == bestCount && dist < bestDist) {
+			best = label
+			bestCount = count
+			bestDist = dist
+		}
+	}
+	return best
+}
+
+// knnPush adds n to h, keeping h a max-heap (by
+// Distance) of at most k elements.
+func knnPush(h *knnHeap, n KNNNeighbor, k int) {
+	if h.Len() < k {
+		heap.Push(h, n)
+	} else if h.Len() > 0 && n.Distance < (*h)[0].Distance {
+		heap.Pop(h)
+		heap.Push(h, n)
+	}
+}
+
+// A knnHeap is a max-heap of KNNNeighbor values,
+// ordered by Distance.
+type knnHeap []KNNNeighbor
+
+func (h knnHeap) Len() int            { return len(h) }
+func (h knnHeap) Less(i, j int) bool  { return h[i].Distance > h[j].Distance }
+func (h knnHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *knnHeap) Push(x interface{}) { *h = append(*h, x.(KNNNeighbor)) }
+func (h *knnHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,84 @@
+package mnist
+
+import "testing"
+
+func TestMajorityLabelTieBreak(t *testing.T) {
+	// Label 1's nearest neighbor (distance 1.0) is closer than
+	// label 2's nearest neighbor (distance 2.0), even though
+	// label 2 appears first among the tied vote counts.
+	neighbors := []KNNNeighbor{
+		{Label: 2, Distance: 2.0},
+		{Label: 1, Distance: 1.0},
+		{Label: 2, Distance: 3.0},
+		{Label: 1, Distance: 10.0},
+	}
+	if l := MajorityLabel(neighbors); l != 1 {
+		t.Errorf("expected label 1, got %d", l)
+	}
+}
+
+func TestMajorityLabelNoTie(t *testing.T) {
+	neighbors := []KNNNeighbor{
+		{Label: 0, Distance: 1.0},
+		{Label: 1, Distance: 2.0},
+		{Label: 1, Distance: 3.0},
+		{Label: 1, Distance: 4.0},
+	}
+	if l := MajorityLabel(neighbors); l != 1 {
+		t.Errorf("expected label 1, got %d", l)
+	}
+}
+
+func TestNewKNNClassifier(t *testing.T) {
+	d := DataSet{
+		Samples: []Sample{
+			{Intensities: []float64{0, 0}, Label: 0},
+			{Intensities: []float64{0, 1}, Label: 0},
+			{Intensities: []float64{10, 10}, Label: 1},
+			{Intensities: []float64{10, 11}, Label: 1},
+		},
+		NumClasses: 2,
+	}
+	classifier := NewKNNClassifier(d, 2, EuclideanMetric)
+
+	if l := classifier([]float64{0, 0.5}); l != 0 {
+		t.Errorf("expected label 0, got %d", l)
+	}
+	if l := classifier([]float64{10, 10.5}); l != 1 {
+		t.Errorf("expected label 1, got %d", l)
+	}
+}
+
+func TestKNNPredictTopK(t *testing.T) {
+	d := DataSet{
+		Samples: []Sample{
+			{Intensities: []float64{0}, Label: 0},
+			{Intensities: []float64{1}, Label: 1},
+			{Intensities: []float64{5}, Label: 2},
+		},
+		NumClasses: 3,
+	}
+	neighbors := KNNPredictTopK(d, []float64{0.1}, 2, EuclideanMetric)
+	if len(neighbors) != 2 {
+		t.Fatalf("expected 2 neighbors, got %d", len(neighbors))
+	}
+	if neighbors[0].Label != 0 || neighbors[1].Label != 1 {
+		t.Errorf("expected nearest neighbors [0, 1], got [%d, %d]",
+			neighbors[0].Label, neighbors[1].Label)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	a := []float64{1, 0}
+	b := []float64{0, 1}
+
+	if d := EuclideanMetric(a, a); d != 0 {
+		t.Errorf("expected 0 distance to self, got %f", d)
+	}
+	if d := L1Metric(a, b); d != 2 {
+		t.Errorf("expected L1 distance 2, got %f", d)
+	}
+	if d := CosineMetric(a, b); d != 1 {
+		t.Errorf("expected cosine distance 1 for orthogonal vectors, got %f", d)
+	}
+}
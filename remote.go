@@ -0,0 +1,181 @@
+package mnist
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Canonical hosts for the alternative IDX-formatted
+// datasets supported by LoadFashionMNIST, LoadKMNIST,
+// and LoadEMNIST.
+const (
+	fashionMNISTBaseURL = "http://fashion-mnist.s3-website.eu-central-1.amazonaws.com/"
+	kmnistBaseURL       = "http://codh.rois.ac.jp/kmnist/dataset/kmnist/"
+
+	// Unlike Fashion-MNIST and KMNIST, NIST does not serve
+	// individual EMNIST split files; it serves them bundled
+	// together in a single zip archive.
+	emnistZipURL = "https://biometrics.nist.gov/cs_links/EMNIST/gzip.zip"
+)
+
+// LoadFashionMNIST loads the Fashion-MNIST training set,
+// downloading and caching the IDX files on first use.
+func LoadFashionMNIST() (DataSet, error) {
+	return loadRemoteDataSet(
+		fashionMNISTBaseURL+"train-images-idx3-ubyte.gz",
+		fashionMNISTBaseURL+"train-labels-idx1-ubyte.gz",
+	)
+}
+
+// LoadKMNIST loads the Kuzushiji-MNIST training set,
+// downloading and caching the IDX files on first use.
+func LoadKMNIST() (DataSet, error) {
+	return loadRemoteDataSet(
+		kmnistBaseURL+"train-images-idx3-ubyte.gz",
+		kmnistBaseURL+"train-labels-idx1-ubyte.gz",
+	)
+}
+
+// LoadEMNIST loads the training set for the given EMNIST
+// split (e.g. "balanced", "byclass", "digits", "letters",
+// "mnist"), downloading NIST's gzip.zip archive (caching
+// it, and the files extracted from it) on first use.
+func LoadEMNIST(split string) (DataSet, error) {
+	prefix := fmt.Sprintf("gzip/emnist-%s-train", split)
+	imagesPath, err := extractEMNISTFile(prefix + "-images-idx3-ubyte.gz")
+	if err != nil {
+		return DataSet{}, err
+	}
+	labelsPath, err := extractEMNISTFile(prefix + "-labels-idx1-ubyte.gz")
+	if err != nil {
+		return DataSet{}, err
+	}
+	return LoadDataSet(imagesPath, labelsPath)
+}
+
+// extractEMNISTFile returns the local, cached path to the
+// file at entryName within NIST's EMNIST zip archive,
+// downloading and extracting the archive on first use.
+func extractEMNISTFile(entryName string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	outPath := filepath.Join(dir, filepath.Base(entryName))
+	if _, err := os.Stat(outPath); err == nil {
+		return outPath, nil
+	}
+
+	zipPath, err := cachedDownload(emnistZipURL)
+	if err != nil {
+		return "", err
+	}
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.Name != entryName {
+			continue
+		}
+		src, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		defer src.Close()
+		return outPath, copyToCachePath(outPath, src)
+	}
+
+	return "", fmt.Errorf("entry %q not found in EMNIST archive", entryName)
+}
+
+// copyToCachePath atomically writes the contents of src to
+// path, via a temporary file in the same directory.
+func copyToCachePath(path string, src io.Reader) error {
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// loadRemoteDataSet downloads (or reuses cached copies
+// of) the IDX files at imagesURL and labelsURL, then
+// loads them with LoadDataSet.
+func loadRemoteDataSet(imagesURL, labelsURL string) (DataSet, error) {
+	imagesPath, err := cachedDownload(imagesURL)
+	if err != nil {
+		return DataSet{}, err
+	}
+	labelsPath, err := cachedDownload(labelsURL)
+	if err != nil {
+		return DataSet{}, err
+	}
+	return LoadDataSet(imagesPath, labelsPath)
+}
+
+// cacheDir returns the directory used to cache
+// downloaded datasets, creating it if necessary.
+func cacheDir() (string, error) {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".cache")
+	}
+	dir := filepath.Join(base, "unixpickle-mnist")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// cachedDownload returns the local path to url's
+// contents, downloading it into the cache directory if
+// it is not already present there.
+func cachedDownload(url string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	// Different datasets can share a basename (e.g. Fashion-MNIST
+	// and KMNIST both end in "train-images-idx3-ubyte.gz"), so the
+	// cache filename is keyed by the full URL, not just its base.
+	sum := sha1.Sum([]byte(url))
+	filename := hex.EncodeToString(sum[:8]) + "-" + filepath.Base(url)
+	path := filepath.Join(dir, filename)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download %s: %s", url, resp.Status)
+	}
+
+	return path, copyToCachePath(path, resp.Body)
+}
@@ -0,0 +1,78 @@
+package mnist
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCachedDownloadAvoidsBasenameCollision(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fashion/train-images-idx3-ubyte.gz":
+			w.Write([]byte("fashion-mnist bytes"))
+		case "/kmnist/train-images-idx3-ubyte.gz":
+			w.Write([]byte("kmnist bytes"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// Both URLs share the same basename, which previously collided
+	// in the cache.
+	fashionPath, err := cachedDownload(server.URL + "/fashion/train-images-idx3-ubyte.gz")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	kmnistPath, err := cachedDownload(server.URL + "/kmnist/train-images-idx3-ubyte.gz")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if fashionPath == kmnistPath {
+		t.Fatalf("expected distinct cache paths, both resolved to %s", fashionPath)
+	}
+
+	fashionBytes, err := ioutil.ReadFile(fashionPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kmnistBytes, err := ioutil.ReadFile(kmnistPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(fashionBytes) != "fashion-mnist bytes" {
+		t.Errorf("expected fashion cache to hold fashion bytes, got %q", fashionBytes)
+	}
+	if string(kmnistBytes) != "kmnist bytes" {
+		t.Errorf("expected kmnist cache to hold kmnist bytes, got %q", kmnistBytes)
+	}
+}
+
+func TestCachedDownloadReusesCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	url := server.URL + "/data.gz"
+	if _, err := cachedDownload(url); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cachedDownload(url); err != nil {
+		t.Fatal(err)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected exactly 1 request for a cached URL, got %d", requests)
+	}
+}